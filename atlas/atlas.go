@@ -0,0 +1,251 @@
+// Package atlas bakes a TTF into a single PNG sprite sheet plus a JSON
+// manifest describing where each glyph landed.
+package atlas
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"sort"
+
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// DefaultWidth is the atlas width used when the caller doesn't override it.
+// Kept a power of two so the output plays nicely with GPU texture uploads.
+const DefaultWidth = 512
+
+// Glyph describes where a single rune landed in the atlas, along with the
+// metrics needed to lay it back out as text.
+type Glyph struct {
+	Rune     rune `json:"rune"`
+	X        int  `json:"x"`
+	Y        int  `json:"y"`
+	W        int  `json:"w"`
+	H        int  `json:"h"`
+	BearingX int  `json:"bearingX"`
+	BearingY int  `json:"bearingY"`
+	Advance  int  `json:"advance"`
+}
+
+// Manifest is the JSON sidecar written alongside the atlas PNG.
+type Manifest struct {
+	Width   int     `json:"width"`
+	Height  int     `json:"height"`
+	Ascent  int     `json:"ascent"`
+	Descent int     `json:"descent"`
+	LineGap int     `json:"lineGap"`
+	Glyphs  []Glyph `json:"glyphs"`
+}
+
+// Options configures a Build call.
+type Options struct {
+	Pts   float64
+	Dpi   float64
+	Width int  // atlas width in pixels; defaults to DefaultWidth
+	Light bool // HasLightMode: render dark-on-light glyphs instead of light-on-dark
+}
+
+// shelf is one row of the shelf/skyline packer: glyphs are placed
+// left-to-right along cursorX until they no longer fit, at which point a
+// new shelf is opened at the current bottom of the atlas.
+type shelf struct {
+	y       int
+	height  int
+	cursorX int
+}
+
+// ASCII returns the printable ASCII rune set, 0x20 through 0x7E.
+func ASCII() []rune {
+	runes := make([]rune, 0, 0x7E-0x20+1)
+	for r := rune(0x20); r <= 0x7E; r++ {
+		runes = append(runes, r)
+	}
+	return runes
+}
+
+// Latin1 returns the printable Latin-1 rune set, 0x20 through 0xFF
+// (skipping the 0x7F-0x9F control range).
+func Latin1() []rune {
+	runes := ASCII()
+	for r := rune(0xA0); r <= 0xFF; r++ {
+		runes = append(runes, r)
+	}
+	return runes
+}
+
+// FromString returns the distinct runes of s, in first-seen order.
+func FromString(s string) []rune {
+	seen := map[rune]bool{}
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		if !seen[r] {
+			seen[r] = true
+			runes = append(runes, r)
+		}
+	}
+	return runes
+}
+
+// Build rasterises every rune once and packs the results into a single
+// RGBA image, returning it alongside the manifest describing each glyph's
+// placement. The manifest's Glyphs slice is sorted by rune codepoint so
+// repeated Builds of the same input produce a stable diff.
+func Build(f *truetype.Font, face font.Face, runes []rune, opts Options) (image.Image, Manifest, error) {
+	if len(runes) == 0 {
+		return nil, Manifest{}, fmt.Errorf("atlas: Build: no runes supplied")
+	}
+	width := opts.Width
+	if width == 0 {
+		width = DefaultWidth
+	}
+
+	type rendered struct {
+		r       rune
+		img     *image.RGBA
+		bearX   int
+		bearY   int
+		advance int
+	}
+
+	renders := make([]rendered, 0, len(runes))
+	for _, r := range runes {
+		b, adv, ok := face.GlyphBounds(r)
+		if !ok {
+			continue
+		}
+		w := (b.Max.X - b.Min.X).Ceil()
+		h := (b.Max.Y - b.Min.Y).Ceil()
+		if w < 0 {
+			w = 0
+		}
+		if h < 0 {
+			h = 0
+		}
+
+		// Inkless runes (space, and similar) still need a manifest
+		// entry for their advance; there's just nothing to rasterise.
+		cell := image.NewRGBA(image.Rect(0, 0, w, h))
+		if w > 0 && h > 0 {
+			fg, bg := image.Black, image.White
+			if !opts.Light {
+				fg, bg = image.White, image.Black
+			}
+			draw.Draw(cell, cell.Bounds(), bg, image.Point{}, draw.Src)
+
+			ctx := freetype.NewContext()
+			ctx.SetDPI(opts.Dpi)
+			ctx.SetFont(f)
+			ctx.SetFontSize(opts.Pts)
+			ctx.SetClip(cell.Bounds())
+			ctx.SetDst(cell)
+			ctx.SetSrc(fg)
+			ctx.SetHinting(font.HintingFull)
+
+			pt := fixed.Point26_6{X: -b.Min.X, Y: -b.Min.Y}
+			if _, err := ctx.DrawString(string(r), pt); err != nil {
+				return nil, Manifest{}, fmt.Errorf("atlas: Build: rendering %q: %w", r, err)
+			}
+		}
+
+		renders = append(renders, rendered{
+			r:       r,
+			img:     cell,
+			bearX:   b.Min.X.Floor(),
+			bearY:   -b.Min.Y.Floor(),
+			advance: adv.Ceil(),
+		})
+	}
+
+	order := make(map[rune]int, len(renders))
+	for i, rr := range renders {
+		order[rr.r] = i
+	}
+	sort.SliceStable(renders, func(i, j int) bool {
+		return renders[i].img.Bounds().Dy() > renders[j].img.Bounds().Dy()
+	})
+
+	shelves := []*shelf{}
+	placements := make([]Glyph, len(renders))
+	height := 0
+
+	for _, rr := range renders {
+		w, h := rr.img.Bounds().Dx(), rr.img.Bounds().Dy()
+
+		var dst *shelf
+		for _, s := range shelves {
+			if s.height >= h && width-s.cursorX >= w {
+				dst = s
+				break
+			}
+		}
+		if dst == nil {
+			dst = &shelf{y: height, height: h, cursorX: 0}
+			shelves = append(shelves, dst)
+			height += h
+		}
+
+		placements[order[rr.r]] = Glyph{
+			Rune:     rr.r,
+			X:        dst.cursorX,
+			Y:        dst.y,
+			W:        w,
+			H:        h,
+			BearingX: rr.bearX,
+			BearingY: rr.bearY,
+			Advance:  rr.advance,
+		}
+		dst.cursorX += w
+	}
+
+	atlasImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for _, rr := range renders {
+		g := placements[order[rr.r]]
+		draw.Draw(atlasImg, image.Rect(g.X, g.Y, g.X+g.W, g.Y+g.H), rr.img, image.Point{}, draw.Src)
+	}
+
+	sort.Slice(placements, func(i, j int) bool { return placements[i].Rune < placements[j].Rune })
+
+	m := face.Metrics()
+	manifest := Manifest{
+		Width:   width,
+		Height:  height,
+		Ascent:  m.Ascent.Ceil(),
+		Descent: m.Descent.Ceil(),
+		LineGap: m.Height.Ceil() - m.Ascent.Ceil() - m.Descent.Ceil(),
+		Glyphs:  placements,
+	}
+
+	return atlasImg, manifest, nil
+}
+
+// Export writes the atlas PNG to pngPath and the manifest JSON to
+// jsonPath.
+func Export(img image.Image, manifest Manifest, pngPath, jsonPath string) error {
+	pngFile, err := os.Create(pngPath)
+	if err != nil {
+		return fmt.Errorf("atlas: Export: %w", err)
+	}
+	defer pngFile.Close()
+	if err := encodePNG(pngFile, img); err != nil {
+		return fmt.Errorf("atlas: Export: %w", err)
+	}
+
+	jsonFile, err := os.Create(jsonPath)
+	if err != nil {
+		return fmt.Errorf("atlas: Export: %w", err)
+	}
+	defer jsonFile.Close()
+	enc := json.NewEncoder(jsonFile)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return fmt.Errorf("atlas: Export: %w", err)
+	}
+
+	return nil
+}
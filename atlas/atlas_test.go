@@ -0,0 +1,50 @@
+package atlas
+
+import "testing"
+
+func TestASCII(t *testing.T) {
+	runes := ASCII()
+	if got, want := len(runes), 0x7E-0x20+1; got != want {
+		t.Fatalf("len(ASCII()) = %d, want %d", got, want)
+	}
+	if runes[0] != 0x20 || runes[len(runes)-1] != 0x7E {
+		t.Fatalf("ASCII() = [%U .. %U], want [U+0020 .. U+007E]", runes[0], runes[len(runes)-1])
+	}
+}
+
+func TestLatin1(t *testing.T) {
+	runes := Latin1()
+	for _, r := range runes {
+		if r >= 0x7F && r <= 0x9F {
+			t.Fatalf("Latin1() includes control rune %U", r)
+		}
+	}
+	if runes[len(runes)-1] != 0xFF {
+		t.Fatalf("Latin1() ends at %U, want U+00FF", runes[len(runes)-1])
+	}
+}
+
+func TestFromString(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []rune
+	}{
+		{"", nil},
+		{"abc", []rune{'a', 'b', 'c'}},
+		{"aabbcc", []rune{'a', 'b', 'c'}},
+		{"bab", []rune{'b', 'a'}},
+	}
+	for _, c := range cases {
+		got := FromString(c.in)
+		if len(got) != len(c.want) {
+			t.Errorf("FromString(%q) = %v, want %v", c.in, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("FromString(%q) = %v, want %v", c.in, got, c.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package encode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"time"
+)
+
+var pngSignature = []byte{0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a}
+
+type pngChunk struct {
+	Type string
+	Data []byte
+}
+
+// splitChunks parses a PNG file's chunk stream into its constituent
+// chunks, skipping the 8-byte signature at the front.
+func splitChunks(pngBytes []byte) ([]pngChunk, error) {
+	if len(pngBytes) < 8 || !bytes.Equal(pngBytes[:8], pngSignature) {
+		return nil, fmt.Errorf("encode: splitChunks: not a PNG stream")
+	}
+	var chunks []pngChunk
+	rest := pngBytes[8:]
+	for len(rest) > 0 {
+		if len(rest) < 12 {
+			return nil, fmt.Errorf("encode: splitChunks: truncated chunk")
+		}
+		length := binary.BigEndian.Uint32(rest[0:4])
+		typ := string(rest[4:8])
+		data := rest[8 : 8+length]
+		chunks = append(chunks, pngChunk{Type: typ, Data: data})
+		rest = rest[12+length:]
+	}
+	return chunks, nil
+}
+
+func chunksOfType(chunks []pngChunk, typ string) []pngChunk {
+	var out []pngChunk
+	for _, c := range chunks {
+		if c.Type == typ {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// writeChunk writes one length-prefixed, CRC-terminated PNG chunk.
+func writeChunk(w io.Writer, typ string, data []byte) error {
+	var lengthBuf [4]byte
+	binary.BigEndian.PutUint32(lengthBuf[:], uint32(len(data)))
+	if _, err := w.Write(lengthBuf[:]); err != nil {
+		return err
+	}
+
+	typeAndData := append([]byte(typ), data...)
+	if _, err := w.Write(typeAndData); err != nil {
+		return err
+	}
+
+	var crcBuf [4]byte
+	binary.BigEndian.PutUint32(crcBuf[:], crc32.ChecksumIEEE(typeAndData))
+	_, err := w.Write(crcBuf[:])
+	return err
+}
+
+func acTLData(numFrames, numPlays int) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[0:4], uint32(numFrames))
+	binary.BigEndian.PutUint32(data[4:8], uint32(numPlays))
+	return data
+}
+
+const (
+	apngDisposeNone = 0
+	apngBlendSource = 0
+)
+
+func fcTLData(seq uint32, bounds image.Rectangle, delay time.Duration) []byte {
+	data := make([]byte, 26)
+	binary.BigEndian.PutUint32(data[0:4], seq)
+	binary.BigEndian.PutUint32(data[4:8], uint32(bounds.Dx()))
+	binary.BigEndian.PutUint32(data[8:12], uint32(bounds.Dy()))
+	binary.BigEndian.PutUint32(data[12:16], 0) // x_offset
+	binary.BigEndian.PutUint32(data[16:20], 0) // y_offset
+	binary.BigEndian.PutUint16(data[20:22], uint16(delay.Milliseconds()))
+	binary.BigEndian.PutUint16(data[22:24], 1000) // delay_den: numerator is in ms
+	data[24] = apngDisposeNone
+	data[25] = apngBlendSource
+	return data
+}
+
+// writeAPNG encodes each frame as a standalone PNG, then restitches their
+// IDAT payloads into an APNG: the first frame's IDAT chunks are kept
+// as-is, and every subsequent frame's IDAT chunks are rewrapped as fdAT
+// chunks prefixed with a sequence number, per the APNG spec's acTL/fcTL/
+// fdAT chunks layered on top of a normal PNG.
+func writeAPNG(frames <-chan image.Image, path string, delay time.Duration, loop int) error {
+	var raw []image.Image
+	for f := range frames {
+		raw = append(raw, f)
+	}
+	if len(raw) == 0 {
+		return fmt.Errorf("encode: writeAPNG: no frames")
+	}
+	all := padFrames(raw)
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("encode: writeAPNG: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := out.Write(pngSignature); err != nil {
+		return fmt.Errorf("encode: writeAPNG: %w", err)
+	}
+
+	var first bytes.Buffer
+	if err := png.Encode(&first, all[0]); err != nil {
+		return fmt.Errorf("encode: writeAPNG: %w", err)
+	}
+	firstChunks, err := splitChunks(first.Bytes())
+	if err != nil {
+		return fmt.Errorf("encode: writeAPNG: %w", err)
+	}
+
+	for _, c := range chunksOfType(firstChunks, "IHDR") {
+		if err := writeChunk(out, "IHDR", c.Data); err != nil {
+			return fmt.Errorf("encode: writeAPNG: %w", err)
+		}
+	}
+	if err := writeChunk(out, "acTL", acTLData(len(all), loop)); err != nil {
+		return fmt.Errorf("encode: writeAPNG: %w", err)
+	}
+
+	seq := uint32(0)
+	if err := writeChunk(out, "fcTL", fcTLData(seq, all[0].Bounds(), delay)); err != nil {
+		return fmt.Errorf("encode: writeAPNG: %w", err)
+	}
+	seq++
+	for _, c := range chunksOfType(firstChunks, "IDAT") {
+		if err := writeChunk(out, "IDAT", c.Data); err != nil {
+			return fmt.Errorf("encode: writeAPNG: %w", err)
+		}
+	}
+
+	for _, frame := range all[1:] {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, frame); err != nil {
+			return fmt.Errorf("encode: writeAPNG: %w", err)
+		}
+		chunks, err := splitChunks(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("encode: writeAPNG: %w", err)
+		}
+
+		if err := writeChunk(out, "fcTL", fcTLData(seq, frame.Bounds(), delay)); err != nil {
+			return fmt.Errorf("encode: writeAPNG: %w", err)
+		}
+		seq++
+
+		for _, c := range chunksOfType(chunks, "IDAT") {
+			fdat := make([]byte, 4+len(c.Data))
+			binary.BigEndian.PutUint32(fdat[0:4], seq)
+			copy(fdat[4:], c.Data)
+			if err := writeChunk(out, "fdAT", fdat); err != nil {
+				return fmt.Errorf("encode: writeAPNG: %w", err)
+			}
+			seq++
+		}
+	}
+
+	return writeChunk(out, "IEND", nil)
+}
@@ -0,0 +1,59 @@
+// Package encode consumes a stream of image.Image frames and writes them
+// out as a still PNG, an animated GIF, or an animated PNG (APNG).
+package encode
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"time"
+)
+
+// Format is one of the supported output formats for a frame sequence.
+type Format string
+
+const (
+	PNG  Format = "png"
+	GIF  Format = "gif"
+	APNG Format = "apng"
+)
+
+// Write drains frames and encodes them to path according to format.
+// delay is the time between frames (for GIF and APNG; ignored for PNG)
+// and loop is the animation's loop count, 0 meaning loop forever.
+func Write(frames <-chan image.Image, format Format, path string, delay time.Duration, loop int) error {
+	switch format {
+	case GIF:
+		return writeGIF(frames, path, delay, loop)
+	case APNG:
+		return writeAPNG(frames, path, delay, loop)
+	case PNG, "":
+		return writeLastPNG(frames, path)
+	default:
+		return fmt.Errorf("encode: Write: unknown format %q", format)
+	}
+}
+
+// writeLastPNG keeps the original out.png behaviour: every frame
+// overwrites the last, so the final frame is what ends up on disk.
+func writeLastPNG(frames <-chan image.Image, path string) error {
+	var last image.Image
+	for f := range frames {
+		last = f
+	}
+	if last == nil {
+		return fmt.Errorf("encode: writeLastPNG: no frames")
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("encode: writeLastPNG: %w", err)
+	}
+	defer out.Close()
+
+	if err := png.Encode(out, last); err != nil {
+		return fmt.Errorf("encode: writeLastPNG: %w", err)
+	}
+	return nil
+}
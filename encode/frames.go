@@ -0,0 +1,40 @@
+package encode
+
+import (
+	"image"
+	"image/draw"
+)
+
+// padFrames pads every frame out to the largest frame's bounds, filled
+// with that frame's own corner colour (layout.Render always paints its
+// background over the whole image first, so the corner pixel is it).
+// Both writeGIF and writeAPNG persist the previous frame's canvas rather
+// than clearing between frames, so without this a shorter frame leaves
+// the taller one's pixels visible below it.
+func padFrames(frames []image.Image) []image.Image {
+	maxW, maxH := 0, 0
+	for _, f := range frames {
+		if d := f.Bounds().Dx(); d > maxW {
+			maxW = d
+		}
+		if d := f.Bounds().Dy(); d > maxH {
+			maxH = d
+		}
+	}
+
+	padded := make([]image.Image, len(frames))
+	for i, f := range frames {
+		b := f.Bounds()
+		if b.Dx() == maxW && b.Dy() == maxH {
+			padded[i] = f
+			continue
+		}
+
+		bg := f.At(b.Min.X, b.Min.Y)
+		canvas := image.NewRGBA(image.Rect(0, 0, maxW, maxH))
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+		draw.Draw(canvas, b, f, b.Min, draw.Src)
+		padded[i] = canvas
+	}
+	return padded
+}
@@ -0,0 +1,49 @@
+package encode
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func solidFrame(w, h int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestPadFramesGrowsSmallerFrames(t *testing.T) {
+	frames := []image.Image{
+		solidFrame(40, 30, color.White),
+		solidFrame(20, 10, color.White),
+	}
+
+	padded := padFrames(frames)
+	for i, f := range padded {
+		if b := f.Bounds(); b.Dx() != 40 || b.Dy() != 30 {
+			t.Fatalf("padded[%d] bounds = %v, want 40x30", i, b)
+		}
+	}
+
+	shorter := padded[1]
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 40; x++ {
+			r, g, b, a := shorter.At(x, y).RGBA()
+			if r != 0xffff || g != 0xffff || b != 0xffff || a != 0xffff {
+				t.Fatalf("padded[1].At(%d,%d) = %v, want opaque white fill", x, y, shorter.At(x, y))
+			}
+		}
+	}
+}
+
+func TestPadFramesLeavesUniformSizeAlone(t *testing.T) {
+	frames := []image.Image{
+		solidFrame(10, 10, color.Black),
+		solidFrame(10, 10, color.Black),
+	}
+	padded := padFrames(frames)
+	if padded[0] != frames[0] || padded[1] != frames[1] {
+		t.Fatalf("padFrames reallocated frames that were already the max size")
+	}
+}
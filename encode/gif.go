@@ -0,0 +1,123 @@
+package encode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"os"
+	"sort"
+	"time"
+)
+
+// paletteSampleFrames is how many leading frames are histogrammed to
+// build the shared palette; the rest of the animation reuses it so
+// colours don't drift from frame to frame.
+const paletteSampleFrames = 8
+
+func writeGIF(frames <-chan image.Image, path string, delay time.Duration, loop int) error {
+	var raw []image.Image
+	for f := range frames {
+		raw = append(raw, f)
+	}
+	if len(raw) == 0 {
+		return fmt.Errorf("encode: writeGIF: no frames")
+	}
+
+	padded := padFrames(raw)
+	all := make([]*image.RGBA, len(padded))
+	for i, f := range padded {
+		all[i] = toRGBA(f)
+	}
+
+	sampleN := paletteSampleFrames
+	if sampleN > len(all) {
+		sampleN = len(all)
+	}
+	pal := buildPalette(all[:sampleN])
+
+	delayHundredths := int(delay / (10 * time.Millisecond))
+
+	g := &gif.GIF{LoopCount: loop}
+	for _, rgba := range all {
+		paletted := image.NewPaletted(rgba.Bounds(), pal)
+		draw.Draw(paletted, rgba.Bounds(), rgba, image.Point{}, draw.Src)
+		g.Image = append(g.Image, paletted)
+		g.Delay = append(g.Delay, delayHundredths)
+		g.Disposal = append(g.Disposal, gif.DisposalNone)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("encode: writeGIF: %w", err)
+	}
+	defer out.Close()
+
+	if err := gif.EncodeAll(out, g); err != nil {
+		return fmt.Errorf("encode: writeGIF: %w", err)
+	}
+	return nil
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba
+}
+
+// buildPalette histograms the pixels of frames and keeps the 256 most
+// common colours, breaking count ties by colour value so the result is
+// deterministic across runs.
+func buildPalette(frames []*image.RGBA) color.Palette {
+	counts := map[color.RGBA]int{}
+	for _, f := range frames {
+		b := f.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				r, g, bl, a := f.At(x, y).RGBA()
+				c := color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(bl >> 8), A: uint8(a >> 8)}
+				counts[c]++
+			}
+		}
+	}
+
+	type entry struct {
+		c     color.RGBA
+		count int
+	}
+	entries := make([]entry, 0, len(counts))
+	for c, n := range counts {
+		entries = append(entries, entry{c, n})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		ci, cj := entries[i].c, entries[j].c
+		if ci.R != cj.R {
+			return ci.R < cj.R
+		}
+		if ci.G != cj.G {
+			return ci.G < cj.G
+		}
+		if ci.B != cj.B {
+			return ci.B < cj.B
+		}
+		return ci.A < cj.A
+	})
+
+	const maxColours = 256
+	if len(entries) > maxColours {
+		entries = entries[:maxColours]
+	}
+
+	pal := make(color.Palette, len(entries))
+	for i, e := range entries {
+		pal[i] = e.c
+	}
+	return pal
+}
@@ -0,0 +1,108 @@
+//go:build example
+// +build example
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/tjweldon/gliffs/layout"
+)
+
+// GammaContext is a layout.Compositor that blends glyph coverage in
+// linear light instead of directly in sRGB. Gamma, if non-zero, is the
+// simple power-law gamma to convert through; zero means use the sRGB
+// piecewise curve instead.
+type GammaContext struct {
+	Gamma float64
+}
+
+// linearRGB holds a colour's channels in linear light, 0..1.
+type linearRGB struct{ R, G, B float64 }
+
+func (gc *GammaContext) Composite(dst draw.Image, dr image.Rectangle, src image.Image, mask image.Image, maskp image.Point) {
+	b := dr.Intersect(dst.Bounds())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			mx, my := maskp.X+(x-dr.Min.X), maskp.Y+(y-dr.Min.Y)
+			_, _, _, ma := mask.At(mx, my).RGBA()
+			if ma == 0 {
+				continue
+			}
+			a := float64(ma) / 0xffff
+
+			fg := gc.toLinear(src.At(x, y))
+			bg := gc.toLinear(dst.At(x, y))
+			blended := linearRGB{
+				R: fg.R*a + bg.R*(1-a),
+				G: fg.G*a + bg.G*(1-a),
+				B: fg.B*a + bg.B*(1-a),
+			}
+			dst.Set(x, y, gc.toSRGB(blended))
+		}
+	}
+}
+
+func (gc *GammaContext) toLinear(c color.Color) linearRGB {
+	r, g, b, _ := c.RGBA()
+	return linearRGB{
+		R: gc.decode(float64(r>>8) / 255),
+		G: gc.decode(float64(g>>8) / 255),
+		B: gc.decode(float64(b>>8) / 255),
+	}
+}
+
+func (gc *GammaContext) toSRGB(l linearRGB) color.RGBA {
+	return color.RGBA{
+		R: encodeByte(gc.encode(l.R)),
+		G: encodeByte(gc.encode(l.G)),
+		B: encodeByte(gc.encode(l.B)),
+		A: 0xff,
+	}
+}
+
+// decode converts one sRGB-encoded channel (0..1) to linear light.
+func (gc *GammaContext) decode(cs float64) float64 {
+	if gc.Gamma != 0 {
+		return math.Pow(cs, gc.Gamma)
+	}
+	if cs <= 0.04045 {
+		return cs / 12.92
+	}
+	return math.Pow((cs+0.055)/1.055, 2.4)
+}
+
+// encode converts one linear-light channel (0..1) back to sRGB.
+func (gc *GammaContext) encode(linear float64) float64 {
+	if linear < 0 {
+		linear = 0
+	} else if linear > 1 {
+		linear = 1
+	}
+	if gc.Gamma != 0 {
+		return math.Pow(linear, 1/gc.Gamma)
+	}
+	if linear <= 0.0031308 {
+		return linear * 12.92
+	}
+	return 1.055*math.Pow(linear, 1/2.4) - 0.055
+}
+
+func encodeByte(c float64) uint8 {
+	return uint8(math.Round(clamp01(c) * 255))
+}
+
+func clamp01(c float64) float64 {
+	if c < 0 {
+		return 0
+	}
+	if c > 1 {
+		return 1
+	}
+	return c
+}
+
+var _ layout.Compositor = (*GammaContext)(nil)
@@ -0,0 +1,37 @@
+//go:build example
+// +build example
+
+package main
+
+import "testing"
+
+func TestGammaContextSRGBRoundTrip(t *testing.T) {
+	gc := &GammaContext{}
+	for _, cs := range []float64{0, 0.01, 0.2, 0.5, 0.9, 1} {
+		linear := gc.decode(cs)
+		got := gc.encode(linear)
+		if diff := got - cs; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("encode(decode(%v)) = %v, want %v", cs, got, cs)
+		}
+	}
+}
+
+func TestGammaContextPowerLawRoundTrip(t *testing.T) {
+	gc := &GammaContext{Gamma: 2.2}
+	for _, cs := range []float64{0, 0.2, 0.5, 0.9, 1} {
+		linear := gc.decode(cs)
+		got := gc.encode(linear)
+		if diff := got - cs; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("encode(decode(%v)) = %v, want %v", cs, got, cs)
+		}
+	}
+}
+
+func TestEncodeByteClamps(t *testing.T) {
+	if got := encodeByte(-1); got != 0 {
+		t.Errorf("encodeByte(-1) = %d, want 0", got)
+	}
+	if got := encodeByte(2); got != 255 {
+		t.Errorf("encodeByte(2) = %d, want 255", got)
+	}
+}
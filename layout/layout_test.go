@@ -0,0 +1,49 @@
+package layout
+
+import (
+	"image"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestWrapText(t *testing.T) {
+	face := basicfont.Face7x13
+	maxWidth := fixed.I(face.Advance * 10)
+
+	lines := WrapText("one two three four", face, maxWidth)
+	if len(lines) == 0 {
+		t.Fatalf("WrapText returned no lines")
+	}
+
+	d := &font.Drawer{Face: face}
+	for _, line := range lines {
+		if w := d.MeasureString(line); w > maxWidth {
+			t.Errorf("line %q measures %v, wider than max width %v", line, w, maxWidth)
+		}
+	}
+}
+
+func TestWrapTextEmpty(t *testing.T) {
+	if lines := WrapText("", basicfont.Face7x13, fixed.I(100)); lines != nil {
+		t.Fatalf("WrapText(\"\") = %v, want nil", lines)
+	}
+}
+
+func TestRenderProducesNonEmptyFrames(t *testing.T) {
+	lines := WrapText("hello world", basicfont.Face7x13, fixed.I(200))
+	frames := Render(lines, basicfont.Face7x13, 200, 2, 1.0, image.White, image.Black, nil)
+
+	n := 0
+	for f := range frames {
+		n++
+		if f.Bounds().Dx() != 200 {
+			t.Errorf("frame width = %d, want 200", f.Bounds().Dx())
+		}
+	}
+	if n == 0 {
+		t.Fatalf("Render produced no frames")
+	}
+}
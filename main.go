@@ -14,12 +14,10 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
 	"image"
 	"image/draw"
-	"image/png"
 	"io/ioutil"
 	"log"
 	"os"
@@ -29,7 +27,11 @@ import (
 	"github.com/alexflint/go-arg"
 	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
+	"github.com/tjweldon/gliffs/atlas"
+	"github.com/tjweldon/gliffs/encode"
+	"github.com/tjweldon/gliffs/layout"
 	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
 )
 
 var text = strings.Join([]string{
@@ -67,47 +69,94 @@ var (
 	dpi      = flag.Float64("dpi", 72, "screen resolution in Dots Per Inch")
 	fontfile = flag.String("fontfile", "./sample.ttf", "filename of the ttf font")
 	hinting  = flag.String("hinting", "full", "none | full")
-	spacing  = flag.Float64("spacing", 1.5, "line spacing (e.g. 2 means double spaced)")
 )
 
 type Cli struct {
-	Dpi    float64 `arg:"--dpi" default:"72" help:"screen resolution in Dots Per Inch"`
-	Pts    float64 `arg:"--pts" help:"The font size in pts" default:"20"`
-	Height int     `arg:"--height" help:"The height of the image in pixels" default:"0"`
-	Width  int     `arg:"--width" help:"The width of the image in pixels" default:"0"`
-	Light  bool    `arg:"--light" help:"Run in light mode" default:"false"`
+	Dpi         float64 `arg:"--dpi" default:"72" help:"screen resolution in Dots Per Inch"`
+	Pts         float64 `arg:"--pts" help:"The font size in pts" default:"20"`
+	Height      int     `arg:"--height" help:"The height of each glyph cell in pixels in stroke mode; fill-mode images grow with the wrapped line count" default:"0"`
+	Width       int     `arg:"--width" help:"The width of the wrapped text image in pixels in fill mode; stroke mode sizes cells by glyph advance" default:"0"`
+	Light       bool    `arg:"--light" help:"Run in light mode" default:"false"`
+	Format      string  `arg:"--format" help:"output format: png | gif | apng" default:"png"`
+	FrameDelay  int     `arg:"--frame-delay" help:"milliseconds between frames for gif/apng" default:"200"`
+	Loop        int     `arg:"--loop" help:"animation loop count for gif/apng, 0 means loop forever" default:"0"`
+	StrokeWidth float64 `arg:"--stroke-width" help:"stroke width in pixels; 0 fills glyphs instead of outlining them" default:"0"`
+	Cap         string  `arg:"--cap" help:"stroke cap style: butt | round | square" default:"butt"`
+	Join        string  `arg:"--join" help:"stroke join style: miter | round | bevel" default:"miter"`
+	MiterLimit  float64 `arg:"--miter-limit" help:"maximum miter length, as a multiple of stroke width, before falling back to a bevel join" default:"4"`
+	Gamma       float64 `arg:"--gamma" help:"gamma to blend glyph coverage through; 0 uses the sRGB piecewise curve instead" default:"2.2"`
+	Spacing     float64 `arg:"--spacing" help:"line spacing (e.g. 2 means double spaced)" default:"1.5"`
 }
 
 func (c Cli) GetDims() (w, h int) { return c.Width, c.Height }
 func (c Cli) GetLightMode() bool  { return c.Light }
 
-var args = func() Cli {
+var args Cli
+
+func parseArgs() Cli {
 	a := &Cli{}
 	arg.MustParse(a)
 	return *a
-}()
+}
+
+// AtlasCli is the `gliffs atlas` subcommand's argument set: bake the
+// font into a sprite sheet instead of rendering the Jabberwocky sample.
+type AtlasCli struct {
+	Dpi      float64 `arg:"--dpi" default:"72" help:"screen resolution in Dots Per Inch"`
+	Pts      float64 `arg:"--pts" help:"The font size in pts" default:"20"`
+	Fontfile string  `arg:"--fontfile" help:"filename of the ttf font" default:"./sample.ttf"`
+	Runes    string  `arg:"--runes" help:"ascii | latin1 | a literal rune string to bake" default:"ascii"`
+	Width    int     `arg:"--width" help:"atlas width in pixels, a power of two" default:"512"`
+	Light    bool    `arg:"--light" help:"bake dark-on-light glyphs instead of light-on-dark" default:"false"`
+	Out      string  `arg:"--out" help:"output path prefix; writes <out>.png and <out>.json" default:"atlas"`
+}
+
+func runAtlas(cliArgs []string) {
+	os.Args = append([]string{os.Args[0]}, cliArgs...)
+	a := &AtlasCli{}
+	arg.MustParse(a)
 
-func NewCtx(f *truetype.Font, whiteOnBlack bool, rgba draw.Image) (ctx *freetype.Context) {
-	// Initialize the ctx.
-	fg, bg := image.Black, image.White
-	if whiteOnBlack {
-		fg, bg = image.White, image.Black
+	typeface, err := LoadFont(a.Fontfile, a.Pts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Build sizes cells and metrics from the face but draws at a.Dpi, so
+	// the face has to use the same DPI (LoadFont's face is at 72).
+	typeface.Face = truetype.NewFace(typeface.Font, &truetype.Options{Size: a.Pts, DPI: a.Dpi})
+
+	var runes []rune
+	switch a.Runes {
+	case "ascii":
+		runes = atlas.ASCII()
+	case "latin1":
+		runes = atlas.Latin1()
+	default:
+		runes = atlas.FromString(a.Runes)
 	}
-	draw.Draw(rgba, rgba.Bounds(), bg, image.ZP, draw.Src)
-	ctx = freetype.NewContext()
-	ctx.SetDPI(*dpi)
-	ctx.SetFont(f)
-	ctx.SetFontSize(args.Pts)
-	ctx.SetClip(rgba.Bounds())
-	ctx.SetDst(rgba)
-	ctx.SetSrc(fg)
-	ctx.SetHinting(font.HintingFull)
-	return ctx
+
+	img, manifest, err := atlas.Build(typeface.Font, typeface.Face, runes, atlas.Options{
+		Pts:   a.Pts,
+		Dpi:   a.Dpi,
+		Width: a.Width,
+		Light: a.Light,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := atlas.Export(img, manifest, a.Out+".png", a.Out+".json"); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %s.png and %s.json OK.", a.Out, a.Out)
 }
 
 type TypeFace struct {
 	Font *truetype.Font
 	Face font.Face
+	// Stroke holds the outline options StrokeGlyph strokes glyphs with;
+	// StrokeGlyph returns an error while it is nil. GenImages always
+	// fills glyphs and does not read it.
+	Stroke *StrokeOptions
 }
 
 func GenWords(text string) <-chan string {
@@ -127,6 +176,13 @@ func GenWords(text string) <-chan string {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "atlas" {
+		runAtlas(os.Args[2:])
+		return
+	}
+
+	args = parseArgs()
+
 	typeface, err := LoadFont(*fontfile, args.Pts)
 	if err != nil {
 		log.Fatal(err)
@@ -135,20 +191,33 @@ func main() {
 		args.Height = int(typeface.Face.Metrics().Height)>>6 + int(typeface.Face.Metrics().Descent)>>6
 	}
 	if args.Width == 0 {
-		w, ok := typeface.Face.GlyphAdvance('$')
-		if !ok {
-			log.Fatal("Could not get glyph width")
-		}
-		args.Width = int(w) >> 6
+		args.Width = defaultWidth
 	}
 	words := GenWords(text)
 
-	results := GenImages(words, args, args, typeface, args.Pts)
-	for img := range results {
-		if err := SaveImg(img, "out.png"); err != nil {
+	var results <-chan image.Image
+	if args.StrokeWidth > 0 {
+		capStyle, err := ParseCapStyle(args.Cap)
+		if err != nil {
+			log.Fatal(err)
+		}
+		joinStyle, err := ParseJoinStyle(args.Join)
+		if err != nil {
 			log.Fatal(err)
 		}
+		typeface.Stroke = &StrokeOptions{Width: args.StrokeWidth, Cap: capStyle, Join: joinStyle, MiterLimit: args.MiterLimit}
+		results = GenStrokedImages(words, args, args, typeface, args.Pts, args.Dpi)
+	} else {
+		results = GenImages(words, args, args, typeface, args.Pts)
 	}
+
+	format := encode.Format(args.Format)
+	outPath := "out." + args.Format
+	delay := time.Duration(args.FrameDelay) * time.Millisecond
+	if err := encode.Write(results, format, outPath, delay, args.Loop); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %s OK.", outPath)
 }
 
 type HasDims interface {
@@ -165,7 +234,7 @@ func LoadFont(path string, size float64) (typeface *TypeFace, err error) {
 	var fontBytes []byte
 
 	// Read the font data.
-	fontBytes, err = ioutil.ReadFile(*fontfile)
+	fontBytes, err = ioutil.ReadFile(path)
 	if err != nil {
 		return
 	}
@@ -214,40 +283,45 @@ func LefToRightConcat(imgs ...draw.Image) (concat draw.Image, err error) {
 	return
 }
 
+// defaultWidth is the wrapped-line width used when --width is unset - wide
+// enough to hold several words per line rather than one.
+const defaultWidth = 800
+
+// linesPerImage is how many wrapped lines go into each streamed image -
+// one "paragraph" of output per tick of the pipeline, in place of the
+// one-glyph-per-tick shape GenImages used to have.
+const linesPerImage = 4
+
+// GenImages wraps the words coming off text against dims' width using
+// package layout (so kerning and glyph bearings are honoured, and lines
+// break instead of running off the edge), then streams the wrapped
+// lines back out linesPerImage at a time.
 func GenImages(text <-chan string, dims HasDims, light HasLightMode, typeface *TypeFace, pts float64) <-chan image.Image {
 	wonb := !light.GetLightMode()
-	work := func(res chan<- image.Image, txt <-chan string, dark bool) {
+	work := func(res chan<- image.Image, words <-chan string, dark bool) {
 		defer close(res)
-		imgs := []draw.Image{}
-		for x := range text {
-			for _, glyph := range x {
-				// create the rgba image
-				glyphImg := image.NewRGBA(image.Rect(0, 0, args.Width, args.Height))
-
-				// Initialise the ctx.
-				ctx := NewCtx(typeface.Font, dark, glyphImg)
-				m := typeface.Face.Metrics()
-				pixelHeight := int(m.Height) >> 6
-				
-				// set the positioning
-				pt := freetype.Pt(0, pixelHeight)
-				
-				// draw
-				ctx.DrawString(string(glyph), pt)
-				imgs = append(imgs, glyphImg)
-			}
-			// concatenate the images and...
-			concat, err := LefToRightConcat(imgs...)
-			if err != nil {
-				log.Fatal(err)
+
+		var sb strings.Builder
+		for w := range words {
+			if sb.Len() > 0 {
+				sb.WriteByte(' ')
 			}
-			// send concat!
-			res <- concat
-			imgs = []draw.Image{}
-			time.Sleep(200 * time.Millisecond)
+			sb.WriteString(w)
+		}
+
+		width, _ := dims.GetDims()
+		lines := layout.WrapText(sb.String(), typeface.Face, fixed.I(width))
+
+		fg, bg := image.Black, image.White
+		if dark {
+			fg, bg = image.White, image.Black
 		}
-		
 
+		compositor := &GammaContext{Gamma: args.Gamma}
+		for img := range layout.Render(lines, typeface.Face, width, linesPerImage, args.Spacing, fg, bg, compositor) {
+			res <- img
+			time.Sleep(200 * time.Millisecond)
+		}
 	}
 
 	out := make(chan image.Image)
@@ -255,23 +329,3 @@ func GenImages(text <-chan string, dims HasDims, light HasLightMode, typeface *T
 
 	return out
 }
-
-func SaveImg(rgba image.Image, path string) error {
-	outFile, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer outFile.Close()
-	b := bufio.NewWriter(outFile)
-	err = png.Encode(b, rgba)
-	if err != nil {
-		return err
-	}
-	err = b.Flush()
-	if err != nil {
-		return err
-	}
-	log.Println("Wrote out.png OK.")
-
-	return nil
-}
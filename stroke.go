@@ -0,0 +1,411 @@
+//go:build example
+// +build example
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log"
+	"math"
+	"time"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// CapStyle is the shape drawn at an open path's endpoints.
+type CapStyle int
+
+const (
+	CapButt CapStyle = iota
+	CapRound
+	CapSquare
+)
+
+// JoinStyle is the shape drawn where two stroked segments meet.
+type JoinStyle int
+
+const (
+	JoinMiter JoinStyle = iota
+	JoinRound
+	JoinBevel
+)
+
+// ParseCapStyle parses the --cap flag's value.
+func ParseCapStyle(s string) (CapStyle, error) {
+	switch s {
+	case "butt":
+		return CapButt, nil
+	case "round":
+		return CapRound, nil
+	case "square":
+		return CapSquare, nil
+	default:
+		return 0, fmt.Errorf("stroke: unknown cap style %q", s)
+	}
+}
+
+// ParseJoinStyle parses the --join flag's value.
+func ParseJoinStyle(s string) (JoinStyle, error) {
+	switch s {
+	case "miter":
+		return JoinMiter, nil
+	case "round":
+		return JoinRound, nil
+	case "bevel":
+		return JoinBevel, nil
+	default:
+		return 0, fmt.Errorf("stroke: unknown join style %q", s)
+	}
+}
+
+// StrokeOptions configures outlined, as opposed to filled, glyph
+// rendering by StrokeGlyph, which strokes a TypeFace's glyphs with these
+// options and returns an error when the TypeFace's Stroke is nil.
+type StrokeOptions struct {
+	Width      float64
+	Cap        CapStyle
+	Join       JoinStyle
+	MiterLimit float64
+}
+
+// flattenTolerance is the maximum deviation, in pixels, allowed between a
+// quadratic curve segment and the line segments approximating it.
+const flattenTolerance = 0.5
+
+type vec2 struct{ X, Y float64 }
+
+func (a vec2) add(b vec2) vec2      { return vec2{a.X + b.X, a.Y + b.Y} }
+func (a vec2) sub(b vec2) vec2      { return vec2{a.X - b.X, a.Y - b.Y} }
+func (a vec2) scale(k float64) vec2 { return vec2{a.X * k, a.Y * k} }
+func (a vec2) length() float64      { return math.Hypot(a.X, a.Y) }
+
+func (a vec2) normalize() vec2 {
+	l := a.length()
+	if l == 0 {
+		return vec2{}
+	}
+	return vec2{a.X / l, a.Y / l}
+}
+
+// perp rotates a vector a quarter turn; used to turn a segment direction
+// into the normal the stroke is offset along.
+func (a vec2) perp() vec2 { return vec2{-a.Y, a.X} }
+
+func midpoint(a, b vec2) vec2 { return vec2{(a.X + b.X) / 2, (a.Y + b.Y) / 2} }
+
+func fx(v fixed.Int26_6) float64 { return float64(v) / 64 }
+
+// flattenContour walks one TrueType contour's on/off-curve points and
+// returns the polyline approximating its quadratic B-splines, subdividing
+// until each segment is within flattenTolerance of the true curve.
+func flattenContour(pts []truetype.Point, tolerance float64) []vec2 {
+	n := len(pts)
+	if n == 0 {
+		return nil
+	}
+
+	at := func(i int) vec2 { p := pts[((i%n)+n)%n]; return vec2{fx(p.X), fx(p.Y)} }
+	onCurve := func(i int) bool { return pts[((i%n)+n)%n].Flags&0x01 != 0 }
+
+	start := 0
+	var startPt vec2
+	found := false
+	for i := 0; i < n; i++ {
+		if onCurve(i) {
+			start, startPt, found = i, at(i), true
+			break
+		}
+	}
+	if !found {
+		// No on-curve points at all: synthesise the implied start point
+		// between the last and first points, and walk from point 0.
+		start = -1
+		startPt = midpoint(at(0), at(n-1))
+	}
+
+	out := []vec2{startPt}
+	cur := startPt
+	for i, count := start, 0; count < n; count++ {
+		i++
+		if onCurve(i) {
+			out = append(out, at(i))
+			cur = at(i)
+			continue
+		}
+
+		ctrl := at(i)
+		var next vec2
+		if onCurve(i + 1) {
+			next = at(i + 1)
+			i++
+			count++
+		} else {
+			next = midpoint(ctrl, at(i+1))
+		}
+		flattenQuadTo(cur, ctrl, next, tolerance, &out)
+		cur = next
+	}
+
+	return out
+}
+
+func flattenQuadTo(p0, ctrl, p1 vec2, tolerance float64, out *[]vec2) {
+	if distToSegment(ctrl, p0, p1) <= tolerance || p1.sub(p0).length() < tolerance {
+		*out = append(*out, p1)
+		return
+	}
+	p01 := midpoint(p0, ctrl)
+	p12 := midpoint(ctrl, p1)
+	p012 := midpoint(p01, p12)
+	flattenQuadTo(p0, p01, p012, tolerance, out)
+	flattenQuadTo(p012, p12, p1, tolerance, out)
+}
+
+func distToSegment(p, a, b vec2) float64 {
+	ab := b.sub(a)
+	if ab.length() == 0 {
+		return p.sub(a).length()
+	}
+	t := (p.sub(a).X*ab.X + p.sub(a).Y*ab.Y) / (ab.X*ab.X + ab.Y*ab.Y)
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+	proj := a.add(ab.scale(t))
+	return p.sub(proj).length()
+}
+
+// strokePolyline builds the filled polygons that approximate stroking
+// poly with the given options: one quad per segment, plus join geometry
+// at each vertex (and cap geometry at the endpoints, for open paths).
+// The pieces are allowed to overlap rather than being unioned by hand:
+// they are all fed to a single vector.Rasterizer, which fills wherever
+// the summed winding is non-zero. The segment quads are all wound the
+// same way; a join piece's winding follows the direction of the turn, so
+// it can be the opposite of the quads'.
+func strokePolyline(poly []vec2, closed bool, opts StrokeOptions) [][]vec2 {
+	n := len(poly)
+	if n < 2 {
+		return nil
+	}
+	half := opts.Width / 2
+
+	segCount := n - 1
+	if closed {
+		segCount = n
+	}
+
+	edge := func(i int) (p0, p1 vec2) { return poly[i], poly[(i+1)%n] }
+
+	var polys [][]vec2
+	normals := make([]vec2, segCount)
+	for i := 0; i < segCount; i++ {
+		p0, p1 := edge(i)
+		dir := p1.sub(p0)
+		if dir.length() == 0 {
+			normals[i] = vec2{}
+			continue
+		}
+		normal := dir.normalize().perp().scale(half)
+		normals[i] = normal
+		polys = append(polys, []vec2{
+			p0.add(normal), p1.add(normal), p1.sub(normal), p0.sub(normal),
+		})
+	}
+
+	joinAt := func(v vec2, nPrev, nCur vec2) {
+		polys = append(polys, strokeJoin(v, nPrev, nCur, half, opts)...)
+		polys = append(polys, strokeJoin(v, nPrev.scale(-1), nCur.scale(-1), half, opts)...)
+	}
+
+	if closed {
+		for i := 0; i < n; i++ {
+			prev := (i - 1 + segCount) % segCount
+			joinAt(poly[i], normals[prev], normals[i%segCount])
+		}
+	} else {
+		for i := 1; i < n-1; i++ {
+			joinAt(poly[i], normals[i-1], normals[i])
+		}
+		polys = append(polys, strokeCap(poly[0], normals[0].scale(-1), half, opts.Cap)...)
+		polys = append(polys, strokeCap(poly[n-1], normals[segCount-1], half, opts.Cap)...)
+	}
+
+	return polys
+}
+
+// strokeJoin fills the wedge between two adjacent offset segments on one
+// side of the path at vertex v, where nPrev/nCur are that side's offset
+// normals for the incoming/outgoing segment.
+func strokeJoin(v, nPrev, nCur vec2, half float64, opts StrokeOptions) [][]vec2 {
+	if nPrev == (vec2{}) || nCur == (vec2{}) {
+		return nil
+	}
+
+	switch opts.Join {
+	case JoinRound:
+		const steps = 6
+		a0 := math.Atan2(nPrev.Y, nPrev.X)
+		a1 := math.Atan2(nCur.Y, nCur.X)
+		da := a1 - a0
+		for da > math.Pi {
+			da -= 2 * math.Pi
+		}
+		for da < -math.Pi {
+			da += 2 * math.Pi
+		}
+		fan := []vec2{v}
+		for s := 0; s <= steps; s++ {
+			a := a0 + da*float64(s)/steps
+			fan = append(fan, v.add(vec2{math.Cos(a) * half, math.Sin(a) * half}))
+		}
+		return [][]vec2{fan}
+
+	case JoinMiter:
+		bisector := nPrev.add(nCur).normalize()
+		cosHalfAngle := nPrev.normalize().X*bisector.X + nPrev.normalize().Y*bisector.Y
+		if cosHalfAngle > 1e-6 {
+			miterLen := half / cosHalfAngle
+			if miterLen/half <= opts.MiterLimit {
+				miterPt := v.add(bisector.scale(miterLen))
+				return [][]vec2{{v.add(nPrev), miterPt, v.add(nCur), v}}
+			}
+		}
+		return [][]vec2{{v.add(nPrev), v.add(nCur), v}}
+
+	default: // JoinBevel
+		return [][]vec2{{v.add(nPrev), v.add(nCur), v}}
+	}
+}
+
+// strokeCap fills the area beyond an open path's endpoint p, whose
+// stroke normal there is n. n.perp() points back along the path, so the
+// outward tangent is n.perp() reversed.
+func strokeCap(p, n vec2, half float64, style CapStyle) [][]vec2 {
+	switch style {
+	case CapSquare:
+		tangent := n.perp().scale(-1).normalize().scale(half)
+		return [][]vec2{{p.add(n), p.add(n).add(tangent), p.sub(n).add(tangent), p.sub(n)}}
+
+	case CapRound:
+		const steps = 8
+		a0 := math.Atan2(n.Y, n.X)
+		fan := []vec2{p}
+		for s := 0; s <= steps; s++ {
+			a := a0 - math.Pi*float64(s)/steps
+			fan = append(fan, p.add(vec2{math.Cos(a) * half, math.Sin(a) * half}))
+		}
+		return [][]vec2{fan}
+
+	default: // CapButt
+		return nil
+	}
+}
+
+// StrokeGlyph rasterises rune r's outline, stroked rather than filled,
+// into a w x h RGBA cell using typeface.Stroke's width, join and miter
+// limit settings. Glyph contours are stroked as closed paths, so the cap
+// setting has no effect here. It extracts the glyph's contours via truetype.GlyphBuf
+// instead of letting freetype fill them directly.
+func StrokeGlyph(typeface *TypeFace, r rune, pts, dpi float64, w, h int, whiteOnBlack bool) (*image.RGBA, error) {
+	if typeface.Stroke == nil {
+		return nil, fmt.Errorf("stroke: StrokeGlyph: typeface has no StrokeOptions")
+	}
+
+	idx := typeface.Font.Index(r)
+	scale := fixed.Int26_6(pts * dpi / 72 * 64)
+
+	var buf truetype.GlyphBuf
+	if err := buf.Load(typeface.Font, scale, idx, font.HintingFull); err != nil {
+		return nil, fmt.Errorf("stroke: StrokeGlyph: %w", err)
+	}
+
+	pixelHeight := float64(h)
+	toPixel := func(p vec2) vec2 { return vec2{p.X, pixelHeight - p.Y} }
+
+	rast := vector.NewRasterizer(w, h)
+	start := 0
+	for _, end := range buf.Ends {
+		contour := flattenContour(buf.Points[start:end], flattenTolerance)
+		for i := range contour {
+			contour[i] = toPixel(contour[i])
+		}
+		for _, poly := range strokePolyline(contour, true, *typeface.Stroke) {
+			addPolygon(rast, poly)
+		}
+		start = end
+	}
+
+	fg, bg := image.Black, image.White
+	if whiteOnBlack {
+		fg, bg = image.White, image.Black
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), bg, image.Point{}, draw.Src)
+	rast.Draw(img, img.Bounds(), fg, image.Point{})
+
+	return img, nil
+}
+
+func addPolygon(rast *vector.Rasterizer, poly []vec2) {
+	if len(poly) < 3 {
+		return
+	}
+	rast.MoveTo(float32(poly[0].X), float32(poly[0].Y))
+	for _, p := range poly[1:] {
+		rast.LineTo(float32(p.X), float32(p.Y))
+	}
+	rast.ClosePath()
+}
+
+// GenStrokedImages is GenImages' counterpart for stroke mode: it
+// renders one glyph cell at a time via StrokeGlyph and concatenates them
+// left to right, the same shape the fill-mode pipeline used before it
+// grew word-wrapping, since outlined poster typography doesn't need
+// paragraph layout.
+func GenStrokedImages(text <-chan string, dims HasDims, light HasLightMode, typeface *TypeFace, pts, dpi float64) <-chan image.Image {
+	wonb := !light.GetLightMode()
+	_, h := dims.GetDims()
+
+	// dims' width is the word-wrapped paragraph width GenImages uses, not
+	// a glyph cell size, so it can't be reused here: every stroked glyph
+	// needs its own uniform cell width instead, the same way the
+	// pre-word-wrap pipeline sized its cells off a single glyph's advance.
+	advance, ok := typeface.Face.GlyphAdvance('$')
+	if !ok {
+		log.Fatal("GenStrokedImages: could not get glyph width")
+	}
+	w := int(advance) >> 6
+
+	work := func(res chan<- image.Image, words <-chan string, whiteOnBlack bool) {
+		defer close(res)
+		imgs := []draw.Image{}
+		for word := range words {
+			for _, r := range word {
+				glyphImg, err := StrokeGlyph(typeface, r, pts, dpi, w, h, whiteOnBlack)
+				if err != nil {
+					log.Fatal(err)
+				}
+				imgs = append(imgs, glyphImg)
+			}
+			concat, err := LefToRightConcat(imgs...)
+			if err != nil {
+				log.Fatal(err)
+			}
+			res <- concat
+			imgs = imgs[:0]
+			time.Sleep(200 * time.Millisecond)
+		}
+	}
+
+	out := make(chan image.Image)
+	go work(out, text, wonb)
+	return out
+}
@@ -0,0 +1,62 @@
+//go:build example
+// +build example
+
+package main
+
+import "testing"
+
+func TestParseCapStyle(t *testing.T) {
+	cases := map[string]CapStyle{"butt": CapButt, "round": CapRound, "square": CapSquare}
+	for s, want := range cases {
+		got, err := ParseCapStyle(s)
+		if err != nil {
+			t.Errorf("ParseCapStyle(%q) returned error: %v", s, err)
+		}
+		if got != want {
+			t.Errorf("ParseCapStyle(%q) = %v, want %v", s, got, want)
+		}
+	}
+	if _, err := ParseCapStyle("bogus"); err == nil {
+		t.Errorf("ParseCapStyle(%q) returned no error", "bogus")
+	}
+}
+
+func TestParseJoinStyle(t *testing.T) {
+	cases := map[string]JoinStyle{"miter": JoinMiter, "round": JoinRound, "bevel": JoinBevel}
+	for s, want := range cases {
+		got, err := ParseJoinStyle(s)
+		if err != nil {
+			t.Errorf("ParseJoinStyle(%q) returned error: %v", s, err)
+		}
+		if got != want {
+			t.Errorf("ParseJoinStyle(%q) = %v, want %v", s, got, want)
+		}
+	}
+	if _, err := ParseJoinStyle("bogus"); err == nil {
+		t.Errorf("ParseJoinStyle(%q) returned no error", "bogus")
+	}
+}
+
+func TestDistToSegment(t *testing.T) {
+	a, b := vec2{0, 0}, vec2{10, 0}
+	cases := []struct {
+		p    vec2
+		want float64
+	}{
+		{vec2{5, 3}, 3},
+		{vec2{-2, 0}, 2},
+		{vec2{12, 0}, 2},
+	}
+	for _, c := range cases {
+		if got := distToSegment(c.p, a, b); got != c.want {
+			t.Errorf("distToSegment(%v, %v, %v) = %v, want %v", c.p, a, b, got, c.want)
+		}
+	}
+}
+
+func TestVec2Perp(t *testing.T) {
+	v := vec2{1, 0}.perp()
+	if v != (vec2{0, 1}) {
+		t.Errorf("vec2{1,0}.perp() = %v, want {0,1}", v)
+	}
+}